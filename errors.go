@@ -0,0 +1,50 @@
+package inform
+
+import "fmt"
+
+// errIncompletePacket indicates that fewer bytes were available than
+// the packet header promised.
+type errIncompletePacket string
+
+func (e errIncompletePacket) Error() string {
+	return fmt.Sprintf("inform: incomplete packet: %s", string(e))
+}
+
+// errInvalidMagic indicates a packet did not start with the "UBNT"
+// magic bytes.
+var errInvalidMagic = fmt.Errorf("inform: invalid magic")
+
+// errInvalidKey indicates an AES key with an unexpected length.
+var errInvalidKey = fmt.Errorf("inform: key must be 16 bytes")
+
+// errInvalidPadding indicates that PKCS7 padding could not be removed
+// from a decrypted payload.
+type errInvalidPadding string
+
+func (e errInvalidPadding) Error() string {
+	return fmt.Sprintf("inform: invalid padding: %s", string(e))
+}
+
+// errFlagNotSupported indicates an unsupported (or unsupported
+// combination of) packet flag.
+type errFlagNotSupported string
+
+func (e errFlagNotSupported) Error() string {
+	return fmt.Sprintf("inform: unsupported flag: %s", string(e))
+}
+
+// errInvalidGCMTag indicates that a payload's AES-128-GCM authentication
+// tag did not verify.
+type errInvalidGCMTag string
+
+func (e errInvalidGCMTag) Error() string {
+	return fmt.Sprintf("inform: GCM tag verification failed: %s", string(e))
+}
+
+// errPayloadTooLarge indicates that a packet's header advertised a
+// payload larger than the configured limit.
+type errPayloadTooLarge string
+
+func (e errPayloadTooLarge) Error() string {
+	return fmt.Sprintf("inform: payload too large: %s", string(e))
+}
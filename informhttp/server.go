@@ -0,0 +1,84 @@
+// Package informhttp turns the inform packet codec into a drop-in
+// controller-side (or man-in-the-middle) HTTP endpoint.
+package informhttp // import "github.com/dmke/inform-inspect/informhttp"
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+
+	inform "github.com/dmke/inform-inspect"
+)
+
+// contentType is the Content-Type UniFi devices use for /inform POSTs.
+const contentType = "application/x-binary"
+
+// Server implements http.Handler for a UniFi-controller-compatible
+// /inform endpoint. KeyFunc resolves the AES key for a device by its
+// MAC address; Handler receives the decoded packet and its decrypted
+// payload, and may return a reply Packet to encode and send back.
+type Server struct {
+	KeyFunc func(mac net.HardwareAddr) ([]byte, error)
+	Handler func(pkt *inform.Packet, data []byte) (*inform.Packet, error)
+
+	// MaxPayloadSize overrides inform.MaxPayloadSize for requests
+	// handled by this Server. Zero means use inform.MaxPayloadSize.
+	MaxPayloadSize int
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ct := r.Header.Get("Content-Type"); ct != contentType {
+		http.Error(w, fmt.Sprintf("unexpected Content-Type %q", ct), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	max := s.MaxPayloadSize
+	if max == 0 {
+		max = inform.MaxPayloadSize
+	}
+
+	pkt, err := inform.ReadPacketLimit(r.Body, max)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := s.KeyFunc(pkt.MAC)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	data, err := pkt.Data(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reply, err := s.Handler(pkt, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if reply == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// encode the reply before anything is written, so an encoding error
+	// can still be reported with a proper status code.
+	var buf bytes.Buffer
+	if err := inform.WritePacket(&buf, reply, key); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,82 @@
+package informhttp
+
+import (
+	"bytes"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	inform "github.com/dmke/inform-inspect"
+)
+
+func testKey() []byte { return []byte("0123456789abcdef") }
+
+func TestServeHTTPRoundTrip(t *testing.T) {
+	key := testKey()
+	mac := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01}
+
+	reqPkt := &inform.Packet{
+		PacketVersion:  1,
+		PayloadVersion: 1,
+		MAC:            mac,
+		Flags:          inform.Encrypted,
+		Payload:        []byte(`{"hello":"controller"}`),
+	}
+
+	var body bytes.Buffer
+	if err := inform.WritePacket(&body, reqPkt, key); err != nil {
+		t.Fatalf("WritePacket: %v", err)
+	}
+
+	srv := &Server{
+		KeyFunc: func(net.HardwareAddr) ([]byte, error) { return key, nil },
+		Handler: func(pkt *inform.Packet, data []byte) (*inform.Packet, error) {
+			if string(data) != string(reqPkt.Payload) {
+				t.Fatalf("got payload %q, want %q", data, reqPkt.Payload)
+			}
+			return &inform.Packet{
+				PacketVersion:  pkt.PacketVersion,
+				PayloadVersion: pkt.PayloadVersion,
+				MAC:            pkt.MAC,
+				Flags:          inform.Encrypted,
+				Payload:        []byte(`{"reply":"ok"}`),
+			}, nil
+		},
+	}
+
+	req := httptest.NewRequest("POST", "/inform", &body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("got status %d, want 200", rec.Code)
+	}
+
+	replyPkt, err := inform.ReadPacket(rec.Body)
+	if err != nil {
+		t.Fatalf("ReadPacket: %v", err)
+	}
+	data, err := replyPkt.Data(key)
+	if err != nil {
+		t.Fatalf("Data: %v", err)
+	}
+	if string(data) != `{"reply":"ok"}` {
+		t.Fatalf("got reply %q", data)
+	}
+}
+
+func TestServeHTTPRejectsWrongContentType(t *testing.T) {
+	srv := &Server{}
+
+	req := httptest.NewRequest("POST", "/inform", bytes.NewReader(nil))
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != 415 {
+		t.Fatalf("got status %d, want 415", rec.Code)
+	}
+}
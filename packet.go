@@ -1,6 +1,8 @@
 package inform // import "github.com/dmke/inform-inspect"
 
 import (
+	"bytes"
+	"compress/zlib"
 	"crypto/aes"
 	"crypto/cipher"
 	"encoding/binary"
@@ -18,8 +20,17 @@ type Packet struct {
 	PayloadVersion uint32           // version of the payload
 	MAC            net.HardwareAddr // Unifi device's MAC address
 	Flags          flags            // 0x01 = encrypted, 0x02 = compressed
-	IV             []byte           // AES-128-CBC initialization vector
+	IV             []byte           // AES-128-CBC initialization vector, or AES-128-GCM nonce
 	Payload        []byte           // payload (usually JSON)
+
+	rawHeader []byte // the raw 40-byte header, as read off the wire
+}
+
+// Header returns the raw 40-byte packet header (magic through payload
+// length) as it was read off the wire. Packets with PayloadVersion == 1
+// use it as additional authenticated data for AES-128-GCM; see Data().
+func (p *Packet) Header() []byte {
+	return p.rawHeader
 }
 
 type fieldName byte
@@ -79,6 +90,15 @@ var fields = []struct {
 
 const hlen = 4 + 4 + 6 + 2 + 16 + 4 + 4
 
+// MaxPayloadSize is the default upper bound, in bytes, on a packet's
+// payload that ReadPacket will allocate a buffer for. The header's
+// payload-length field is 32 bits wide, so without a cap a corrupt or
+// malicious sender could make ReadPacket allocate up to 4 GiB before
+// any other validation runs. Inform payloads are JSON and tend to run
+// larger than e.g. SSH packets, so this is set higher than OpenSSH's
+// 256 KiB hard cap. Use ReadPacketLimit to apply a different limit.
+var MaxPayloadSize = 1 << 20 // 1 MiB
+
 // ReadPacket tries to decode the input into a Packet instance.
 //
 // The reader is read from twice: once to fetch the header (which has a
@@ -89,18 +109,24 @@ const hlen = 4 + 4 + 6 + 2 + 16 + 4 + 4
 // The returned Packet is nil if there's an error. You should not access
 // its payload directly, but use the Data() function, which takes care
 // of decrypting and decompressing (if necessary).
+//
+// Payloads larger than MaxPayloadSize are rejected with
+// errPayloadTooLarge before a buffer for them is allocated; use
+// ReadPacketLimit to apply a different limit.
 func ReadPacket(r io.Reader) (*Packet, error) {
+	return ReadPacketLimit(r, MaxPayloadSize)
+}
+
+// ReadPacketLimit behaves like ReadPacket, but rejects payloads larger
+// than max bytes before allocating a buffer for them.
+func ReadPacketLimit(r io.Reader, max int) (*Packet, error) {
 	head := make([]byte, hlen)
-	n, err := r.Read(head)
-	if err != nil {
-		return nil, err
-	}
-	if n != hlen {
-		return nil, errIncompletePacket("header too short")
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, errIncompletePacket(err.Error())
 	}
 
 	off := 0
-	pkt := &Packet{}
+	pkt := &Packet{rawHeader: head}
 	for _, f := range fields {
 		curr := head[off : off+f.length]
 		switch f.name {
@@ -110,6 +136,9 @@ func ReadPacket(r io.Reader) (*Packet, error) {
 			}
 		case hPayloadLength:
 			val := binary.BigEndian.Uint32(curr)
+			if val > uint32(max) {
+				return nil, errPayloadTooLarge(fmt.Sprintf("%d bytes exceeds limit of %d bytes", val, max))
+			}
 			pkt.Payload = make([]byte, val)
 		}
 
@@ -124,7 +153,7 @@ func ReadPacket(r io.Reader) (*Packet, error) {
 		return nil, errIncompletePacket("header does not define payload length")
 	}
 
-	if _, err = io.ReadFull(r, pkt.Payload); err != nil {
+	if _, err := io.ReadFull(r, pkt.Payload); err != nil {
 		return nil, errIncompletePacket(err.Error())
 	}
 
@@ -152,29 +181,55 @@ func (p *Packet) Data(key []byte) (res []byte, err error) {
 	res = p.Payload
 
 	if p.Flags&Encrypted != 0 {
-		if res, err = decrypt(key, p.IV, p.Payload); err != nil {
-			return nil, err
+		switch p.PayloadVersion {
+		case 1:
+			if res, err = decryptGCM(key, p.IV, p.rawHeader, p.Payload); err != nil {
+				return nil, err
+			}
+		default:
+			if res, err = decrypt(key, p.IV, p.Payload); err != nil {
+				return nil, err
+			}
 		}
 	}
 
+	if p.Flags&Compressed != 0 && p.Flags&SnappyCompressed != 0 {
+		return nil, errFlagNotSupported("compressed and snappy-compressed are mutually exclusive")
+	}
+
 	if p.Flags&Compressed != 0 {
-		return nil, errFlagNotSupported("compressed")
+		if res, err = inflateZlib(res); err != nil {
+			return nil, err
+		}
 	}
 
 	if p.Flags&SnappyCompressed != 0 {
-		if res, err = deflate(res); err != nil {
-			return nil, nil
+		if res, err = inflateSnappy(res); err != nil {
+			return nil, err
 		}
 	}
 
 	return
 }
 
-// deflate decompresses data
-func deflate(data []byte) ([]byte, error) {
+// inflateSnappy decompresses data that was compressed with Google's
+// snappy algorithm; it is the decoding counterpart to deflateSnappy.
+func inflateSnappy(data []byte) ([]byte, error) {
 	return snappy.Decode(nil, data[:len(data)-10])
 }
 
+// inflateZlib decompresses data that was zlib-deflated, as emitted by
+// older UniFi firmware when the Compressed flag is set.
+func inflateZlib(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
 // decrypt decodes the payload with the given key. The key must be 16
 // bytes long.
 func decrypt(key, iv, data []byte) ([]byte, error) {
@@ -196,6 +251,33 @@ func decrypt(key, iv, data []byte) ([]byte, error) {
 	return pkcs7unpad(ciphertext)
 }
 
+// gcmOverhead is the size of the authentication tag AES-GCM appends to
+// the ciphertext.
+const gcmOverhead = 16
+
+// decryptGCM decodes the payload with the given key, using AES-128-GCM
+// as employed by inform packets with PayloadVersion == 1. The 16-byte
+// iv doubles as the GCM nonce, and aad (the raw packet header) is
+// verified alongside the ciphertext's authentication tag. The key must
+// be 16 bytes long.
+func decryptGCM(key, iv, aad, data []byte) ([]byte, error) {
+	if len(key) != 16 {
+		return nil, errInvalidKey
+	}
+
+	block, _ := aes.NewCipher(key)
+	gcm, err := cipher.NewGCMWithNonceSize(block, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := gcm.Open(nil, iv, data, aad)
+	if err != nil {
+		return nil, errInvalidGCMTag(err.Error())
+	}
+	return res, nil
+}
+
 // pkcs7unpad removes padding from a decoded stream.
 func pkcs7unpad(b []byte) ([]byte, error) {
 	if len(b) == 0 {
@@ -0,0 +1,106 @@
+package inform
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"testing"
+)
+
+// slowReader returns at most one byte per Read call, to exercise
+// readers that don't fill the buffer in one call (e.g. chunked HTTP
+// request bodies).
+type slowReader struct {
+	data []byte
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	if len(s.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, s.data[:1])
+	s.data = s.data[1:]
+	return n, nil
+}
+
+func TestReadPacketHandlesPartialReads(t *testing.T) {
+	key := testKey()
+	p := &Packet{
+		PacketVersion:  1,
+		PayloadVersion: 0,
+		MAC:            testMAC(),
+		Flags:          Encrypted,
+		Payload:        []byte(`{"hello":"chunked"}`),
+	}
+
+	var buf bytes.Buffer
+	if err := WritePacket(&buf, p, key); err != nil {
+		t.Fatalf("WritePacket: %v", err)
+	}
+
+	got, err := ReadPacket(&slowReader{data: buf.Bytes()})
+	if err != nil {
+		t.Fatalf("ReadPacket: %v", err)
+	}
+
+	data, err := got.Data(key)
+	if err != nil {
+		t.Fatalf("Data: %v", err)
+	}
+	if string(data) != string(p.Payload) {
+		t.Fatalf("got payload %q, want %q", data, p.Payload)
+	}
+}
+
+func TestReadPacketLimitRejectsOversizedPayload(t *testing.T) {
+	key := testKey()
+	p := &Packet{
+		PacketVersion:  1,
+		PayloadVersion: 0,
+		MAC:            testMAC(),
+		Payload:        make([]byte, 64),
+	}
+
+	var buf bytes.Buffer
+	if err := WritePacket(&buf, p, key); err != nil {
+		t.Fatalf("WritePacket: %v", err)
+	}
+
+	_, err := ReadPacketLimit(bytes.NewReader(buf.Bytes()), 16)
+	if err == nil {
+		t.Fatal("ReadPacketLimit: expected errPayloadTooLarge, got nil")
+	} else if _, ok := err.(errPayloadTooLarge); !ok {
+		t.Fatalf("ReadPacketLimit: got error %T, want errPayloadTooLarge", err)
+	}
+}
+
+func TestDataZlibCompressed(t *testing.T) {
+	want := []byte(`{"hello":"zlib"}`)
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(want); err != nil {
+		t.Fatalf("zlib.Write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zlib.Close: %v", err)
+	}
+
+	p := &Packet{Flags: Compressed, Payload: buf.Bytes()}
+	got, err := p.Data(nil)
+	if err != nil {
+		t.Fatalf("Data: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestDataRejectsBothCompressionFlags(t *testing.T) {
+	p := &Packet{Flags: Compressed | SnappyCompressed, Payload: []byte("irrelevant")}
+	if _, err := p.Data(nil); err == nil {
+		t.Fatal("Data: expected error for mutually exclusive compression flags, got nil")
+	} else if _, ok := err.(errFlagNotSupported); !ok {
+		t.Fatalf("Data: got error %T, want errFlagNotSupported", err)
+	}
+}
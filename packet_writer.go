@@ -0,0 +1,190 @@
+package inform
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/golang/snappy"
+)
+
+// MarshalBinary encodes p's current field values into the wire format:
+// the "UBNT" magic, the header fields (in the order described by
+// fields), and p.Payload verbatim. It does not compress or encrypt the
+// payload; use WritePacket to produce a packet from plaintext.
+func (p *Packet) MarshalBinary() ([]byte, error) {
+	if len(p.MAC) != 6 {
+		return nil, errIncompletePacket("MAC must be 6 bytes")
+	}
+	if len(p.IV) != 16 {
+		return nil, errIncompletePacket("IV must be 16 bytes")
+	}
+
+	buf := make([]byte, 0, hlen+len(p.Payload))
+	buf = append(buf, p.buildHeader(len(p.Payload))...)
+	buf = append(buf, p.Payload...)
+	return buf, nil
+}
+
+// buildHeader assembles the 40-byte packet header in the order
+// described by fields, using payloadLen as the (possibly already
+// encrypted) payload length.
+func (p *Packet) buildHeader(payloadLen int) []byte {
+	head := make([]byte, hlen)
+	off := 0
+	for _, f := range fields {
+		curr := head[off : off+f.length]
+		switch f.name {
+		case hMagic:
+			copy(curr, "UBNT")
+		case hPacketVersion:
+			binary.BigEndian.PutUint32(curr, p.PacketVersion)
+		case hMAC:
+			copy(curr, p.MAC)
+		case hFlags:
+			binary.BigEndian.PutUint16(curr, uint16(p.Flags))
+		case hIV:
+			copy(curr, p.IV)
+		case hPayloadVersion:
+			binary.BigEndian.PutUint32(curr, p.PayloadVersion)
+		case hPayloadLength:
+			binary.BigEndian.PutUint32(curr, uint32(payloadLen))
+		}
+		off += f.length
+	}
+	return head
+}
+
+// WritePacket compresses and encrypts p's plaintext Payload as
+// indicated by p.Flags and p.PayloadVersion, then writes the resulting
+// packet to w. The wire format always carries a 16-byte IV field, so if
+// p.IV is empty, a fresh random one is generated and stored back onto
+// p, regardless of whether Encrypted is set. p itself is left with its
+// original, plaintext Payload.
+func WritePacket(w io.Writer, p *Packet, key []byte) error {
+	payload := p.Payload
+
+	if len(p.IV) == 0 {
+		p.IV = make([]byte, 16)
+		if _, err := rand.Read(p.IV); err != nil {
+			return err
+		}
+	}
+
+	if p.Flags&Compressed != 0 && p.Flags&SnappyCompressed != 0 {
+		return errFlagNotSupported("compressed and snappy-compressed are mutually exclusive")
+	}
+
+	if p.Flags&Compressed != 0 {
+		var err error
+		if payload, err = deflateZlib(payload); err != nil {
+			return err
+		}
+	}
+
+	if p.Flags&SnappyCompressed != 0 {
+		payload = deflateSnappy(payload)
+	}
+
+	if p.Flags&Encrypted != 0 {
+		var err error
+		switch p.PayloadVersion {
+		case 1:
+			aad := p.buildHeader(len(payload) + gcmOverhead)
+			payload, err = encryptGCM(key, p.IV, aad, payload)
+		default:
+			payload, err = encrypt(key, p.IV, payload)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	out := &Packet{
+		PacketVersion:  p.PacketVersion,
+		PayloadVersion: p.PayloadVersion,
+		MAC:            p.MAC,
+		Flags:          p.Flags,
+		IV:             p.IV,
+		Payload:        payload,
+	}
+
+	buf, err := out.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// deflateSnappy compresses data with Google's snappy algorithm; it is
+// the encoding counterpart to inflateSnappy.
+func deflateSnappy(data []byte) []byte {
+	return append(snappy.Encode(nil, data), make([]byte, 10)...)
+}
+
+// deflateZlib compresses data with zlib; it is the encoding counterpart
+// to inflateZlib.
+func deflateZlib(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encrypt encodes the payload with the given key, using AES-128-CBC and
+// PKCS7 padding. The key must be 16 bytes long.
+func encrypt(key, iv, data []byte) ([]byte, error) {
+	if len(key) != 16 {
+		return nil, errInvalidKey
+	}
+
+	padded := pkcs7pad(data, aes.BlockSize)
+
+	block, _ := aes.NewCipher(key)
+	mode := cipher.NewCBCEncrypter(block, iv)
+	mode.CryptBlocks(padded, padded)
+
+	return padded, nil
+}
+
+// encryptGCM encodes the payload with the given key, using AES-128-GCM
+// as employed by inform packets with PayloadVersion == 1. The 16-byte
+// iv doubles as the GCM nonce, and aad (the packet header with the
+// final payload length already filled in) is authenticated alongside
+// the ciphertext. The key must be 16 bytes long.
+func encryptGCM(key, iv, aad, data []byte) ([]byte, error) {
+	if len(key) != 16 {
+		return nil, errInvalidKey
+	}
+
+	block, _ := aes.NewCipher(key)
+	gcm, err := cipher.NewGCMWithNonceSize(block, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nil, iv, data, aad), nil
+}
+
+// pkcs7pad appends PKCS7 padding to b so its length becomes a multiple
+// of blockSize.
+func pkcs7pad(b []byte, blockSize int) []byte {
+	n := blockSize - len(b)%blockSize
+	padded := make([]byte, len(b)+n)
+	copy(padded, b)
+	for i := len(b); i < len(padded); i++ {
+		padded[i] = byte(n)
+	}
+	return padded
+}
@@ -0,0 +1,206 @@
+package inform
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func testKey() []byte {
+	return []byte("0123456789abcdef")
+}
+
+func testMAC() net.HardwareAddr {
+	return net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01}
+}
+
+func roundTrip(t *testing.T, p *Packet, key []byte) *Packet {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := WritePacket(&buf, p, key); err != nil {
+		t.Fatalf("WritePacket: %v", err)
+	}
+
+	got, err := ReadPacket(&buf)
+	if err != nil {
+		t.Fatalf("ReadPacket: %v", err)
+	}
+	return got
+}
+
+func TestWritePacketRoundTripCBC(t *testing.T) {
+	key := testKey()
+	p := &Packet{
+		PacketVersion:  1,
+		PayloadVersion: 0,
+		MAC:            testMAC(),
+		Flags:          Encrypted,
+		Payload:        []byte(`{"hello":"world"}`),
+	}
+
+	got := roundTrip(t, p, key)
+	data, err := got.Data(key)
+	if err != nil {
+		t.Fatalf("Data: %v", err)
+	}
+	if string(data) != string(p.Payload) {
+		t.Fatalf("got payload %q, want %q", data, p.Payload)
+	}
+}
+
+func TestWritePacketRoundTripGCM(t *testing.T) {
+	key := testKey()
+	p := &Packet{
+		PacketVersion:  1,
+		PayloadVersion: 1,
+		MAC:            testMAC(),
+		Flags:          Encrypted,
+		Payload:        []byte(`{"hello":"gcm"}`),
+	}
+
+	got := roundTrip(t, p, key)
+	data, err := got.Data(key)
+	if err != nil {
+		t.Fatalf("Data: %v", err)
+	}
+	if string(data) != string(p.Payload) {
+		t.Fatalf("got payload %q, want %q", data, p.Payload)
+	}
+}
+
+func TestWritePacketRoundTripSnappy(t *testing.T) {
+	key := testKey()
+	p := &Packet{
+		PacketVersion:  1,
+		PayloadVersion: 0,
+		MAC:            testMAC(),
+		Flags:          SnappyCompressed,
+		Payload:        []byte(`{"hello":"snappy"}`),
+	}
+
+	got := roundTrip(t, p, key)
+	data, err := got.Data(key)
+	if err != nil {
+		t.Fatalf("Data: %v", err)
+	}
+	if string(data) != string(p.Payload) {
+		t.Fatalf("got payload %q, want %q", data, p.Payload)
+	}
+}
+
+func TestWritePacketRoundTripZlib(t *testing.T) {
+	key := testKey()
+	p := &Packet{
+		PacketVersion:  1,
+		PayloadVersion: 0,
+		MAC:            testMAC(),
+		Flags:          Compressed,
+		Payload:        []byte(`{"hello":"zlib-write"}`),
+	}
+
+	got := roundTrip(t, p, key)
+	data, err := got.Data(key)
+	if err != nil {
+		t.Fatalf("Data: %v", err)
+	}
+	if string(data) != string(p.Payload) {
+		t.Fatalf("got payload %q, want %q", data, p.Payload)
+	}
+}
+
+func TestWritePacketRejectsBothCompressionFlags(t *testing.T) {
+	p := &Packet{
+		PacketVersion: 1,
+		MAC:           testMAC(),
+		Flags:         Compressed | SnappyCompressed,
+		Payload:       []byte("irrelevant"),
+	}
+
+	var buf bytes.Buffer
+	err := WritePacket(&buf, p, nil)
+	if err == nil {
+		t.Fatal("WritePacket: expected error for mutually exclusive compression flags, got nil")
+	} else if _, ok := err.(errFlagNotSupported); !ok {
+		t.Fatalf("WritePacket: got error %T, want errFlagNotSupported", err)
+	}
+}
+
+func TestWritePacketFillsMissingIVWhenUnencrypted(t *testing.T) {
+	p := &Packet{
+		PacketVersion:  1,
+		PayloadVersion: 0,
+		MAC:            testMAC(),
+		Payload:        []byte(`{"hello":"plain"}`),
+	}
+
+	var buf bytes.Buffer
+	if err := WritePacket(&buf, p, nil); err != nil {
+		t.Fatalf("WritePacket: %v", err)
+	}
+	if len(p.IV) != 16 {
+		t.Fatalf("got IV length %d, want 16", len(p.IV))
+	}
+
+	got, err := ReadPacket(&buf)
+	if err != nil {
+		t.Fatalf("ReadPacket: %v", err)
+	}
+	if string(got.Payload) != string(p.Payload) {
+		t.Fatalf("got payload %q, want %q", got.Payload, p.Payload)
+	}
+}
+
+func TestGCMTagVerificationFailure(t *testing.T) {
+	key := testKey()
+	p := &Packet{
+		PacketVersion:  1,
+		PayloadVersion: 1,
+		MAC:            testMAC(),
+		Flags:          Encrypted,
+		Payload:        []byte(`{"hello":"tampered"}`),
+	}
+
+	var buf bytes.Buffer
+	if err := WritePacket(&buf, p, key); err != nil {
+		t.Fatalf("WritePacket: %v", err)
+	}
+
+	raw := buf.Bytes()
+	raw[len(raw)-1] ^= 0xff // flip the bits of the GCM tag's last byte
+
+	got, err := ReadPacket(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadPacket: %v", err)
+	}
+	if _, err := got.Data(key); err == nil {
+		t.Fatal("Data: expected GCM tag verification error, got nil")
+	} else if _, ok := err.(errInvalidGCMTag); !ok {
+		t.Fatalf("Data: got error %T, want errInvalidGCMTag", err)
+	}
+}
+
+func TestPKCS7UnpadBadPadding(t *testing.T) {
+	// last byte claims 4 bytes of padding, but they don't all match it
+	if _, err := pkcs7unpad([]byte{1, 2, 3, 4}); err == nil {
+		t.Fatal("pkcs7unpad: expected padding error, got nil")
+	} else if _, ok := err.(errInvalidPadding); !ok {
+		t.Fatalf("pkcs7unpad: got error %T, want errInvalidPadding", err)
+	}
+}
+
+func TestPKCS7PadUnpadRoundTrip(t *testing.T) {
+	data := []byte("short")
+	padded := pkcs7pad(data, 16)
+	if len(padded)%16 != 0 {
+		t.Fatalf("got padded length %d, want multiple of 16", len(padded))
+	}
+
+	unpadded, err := pkcs7unpad(padded)
+	if err != nil {
+		t.Fatalf("pkcs7unpad: %v", err)
+	}
+	if string(unpadded) != string(data) {
+		t.Fatalf("got %q, want %q", unpadded, data)
+	}
+}